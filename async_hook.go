@@ -0,0 +1,93 @@
+package logger
+
+import "sync"
+
+// AsyncHook 把一个同步的 hook 函数包装成异步执行：日志条目先放进一个有
+// 缓冲的 channel，由固定数量的 worker 协程消费，避免慢速的 Kafka/ES 推送
+// 阻塞写日志的 goroutine。缓冲区满时按 Block 决定丢弃还是阻塞等待。
+type AsyncHook struct {
+	fn     func(LogInfo) error
+	onDrop func(LogInfo)
+	// Block 为 true 时，缓冲区满后 Handle 会阻塞直到有空位；
+	// 为 false（默认）时直接丢弃并回调 onDrop
+	Block bool
+
+	queue chan LogInfo
+	wg    sync.WaitGroup
+
+	// mu 保护 closed：Handle 持有读锁投递，Close 持有写锁把 closed 置
+	// true 之后才关闭 queue，这样 Close 和并发的 Handle 之间不会出现
+	// send on closed channel
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAsyncHook 创建一个 AsyncHook，并启动 workers 个协程消费大小为 bufSize
+// 的队列。onDrop 可以为 nil，此时丢弃的日志条目不会被观察到。
+func NewAsyncHook(fn func(LogInfo) error, bufSize int, workers int, onDrop func(LogInfo)) *AsyncHook {
+	if bufSize <= 0 {
+		bufSize = 1
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	h := &AsyncHook{
+		fn:     fn,
+		onDrop: onDrop,
+		queue:  make(chan LogInfo, bufSize),
+	}
+
+	h.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go h.worker()
+	}
+
+	return h
+}
+
+func (h *AsyncHook) worker() {
+	defer h.wg.Done()
+	for info := range h.queue {
+		// 推送失败时没有上游可以上报错误，交由 fn 自己决定是否重试或记录
+		_ = h.fn(info)
+	}
+}
+
+// Handle 符合 InitLogger 的 hooks 参数签名，可以直接传给 InitLogger 或 New。
+// Close 之后调用是安全的，此时日志条目直接被丢弃
+func (h *AsyncHook) Handle(info LogInfo) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return nil
+	}
+
+	if h.Block {
+		h.queue <- info
+		return nil
+	}
+
+	select {
+	case h.queue <- info:
+	default:
+		if h.onDrop != nil {
+			h.onDrop(info)
+		}
+	}
+	return nil
+}
+
+// Close 关闭队列并等待所有在途的日志条目被消费完毕，重复调用是安全的
+func (h *AsyncHook) Close() {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed = true
+	h.mu.Unlock()
+
+	close(h.queue)
+	h.wg.Wait()
+}
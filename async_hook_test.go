@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAsyncHook(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+
+	hook := NewAsyncHook(func(info LogInfo) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, info.Message)
+		return nil
+	}, 8, 2, nil)
+
+	for i := 0; i < 5; i++ {
+		hook.Handle(LogInfo{Message: "msg"})
+	}
+	hook.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 5 {
+		t.Fatalf("got %d messages, want 5", len(received))
+	}
+}
+
+func TestAsyncHookDrop(t *testing.T) {
+	block := make(chan struct{})
+	var dropped int
+	var mu sync.Mutex
+
+	hook := NewAsyncHook(func(info LogInfo) error {
+		<-block
+		return nil
+	}, 1, 1, func(info LogInfo) {
+		mu.Lock()
+		dropped++
+		mu.Unlock()
+	})
+
+	for i := 0; i < 5; i++ {
+		hook.Handle(LogInfo{Message: "msg"})
+	}
+	close(block)
+	hook.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if dropped == 0 {
+		t.Fatalf("expected at least one dropped message when the queue is full")
+	}
+}
+
+func TestAsyncHookHandleDuringClose(t *testing.T) {
+	hook := NewAsyncHook(func(info LogInfo) error {
+		return nil
+	}, 8, 2, nil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			hook.Handle(LogInfo{Message: "msg"})
+		}
+	}()
+
+	hook.Close()
+	wg.Wait()
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirectStderr 把 os.Stderr 的底层 fd 重定向到 filename，这样之后所有写入
+// os.Stderr 的内容（包括 Go runtime 自身的 panic 输出）都会落盘，而不是随
+// 进程退出一起丢失
+func redirectStderr(filename string) error {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return syscall.Dup2(int(f.Fd()), int(os.Stderr.Fd()))
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package logger
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// crashLogFile 持有重定向用的文件。和 Unix 的 dup2 不同，Windows 的
+// SetStdHandle 只是把标准错误指向同一个句柄，并不会复制一份，所以这里必须
+// 一直持有 f、不能 Close，否则刚设置好的标准错误句柄也会失效。
+var crashLogFile *os.File
+
+// redirectStderr 把标准错误句柄重定向到 filename。Windows 下没有 dup2，
+// 用 SetStdHandle 替换 STD_ERROR_HANDLE 实现同样的效果
+func redirectStderr(filename string) error {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(f.Fd())); err != nil {
+		f.Close()
+		return err
+	}
+	crashLogFile = f
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build elasticsearch
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// ElasticsearchHook 把日志条目以 JSON 文档的形式索引到 Elasticsearch。配合
+// NewAsyncHook 使用可以避免推送阻塞写日志的 goroutine，例如：
+//
+//	hook := NewAsyncHook(ElasticsearchHook(url, "app-log"), 1024, 4, nil)
+//	InitLogger(opt, hook.Handle)
+//
+// 需要用 -tags elasticsearch 编译才会链接 elastic/go-elasticsearch。
+func ElasticsearchHook(url, index string) func(LogInfo) error {
+	es, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{url},
+	})
+	if err != nil {
+		return func(LogInfo) error { return err }
+	}
+
+	return func(info LogInfo) error {
+		body, err := json.Marshal(info)
+		if err != nil {
+			return err
+		}
+
+		req := esapi.IndexRequest{
+			Index: index,
+			Body:  bytes.NewReader(body),
+		}
+		res, err := req.Do(context.Background(), es)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return fmt.Errorf("elasticsearch: index request failed: %s", res.String())
+		}
+		return nil
+	}
+}
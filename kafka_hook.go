@@ -0,0 +1,30 @@
+//go:build kafka
+
+package logger
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaHook 把日志条目编码后推送到 Kafka。配合 NewAsyncHook 使用可以避免
+// 推送阻塞写日志的 goroutine，例如：
+//
+//	hook := NewAsyncHook(KafkaHook(brokers, "app-log", jsonEncoder), 1024, 4, nil)
+//	InitLogger(opt, hook.Handle)
+//
+// 需要用 -tags kafka 编译才会链接 segmentio/kafka-go。
+func KafkaHook(brokers []string, topic string, encoder func(LogInfo) []byte) func(LogInfo) error {
+	w := &kafka.Writer{
+		Addr:     kafka.TCP(brokers...),
+		Topic:    topic,
+		Balancer: &kafka.LeastBytes{},
+	}
+
+	return func(info LogInfo) error {
+		return w.WriteMessages(context.Background(), kafka.Message{
+			Value: encoder(info),
+		})
+	}
+}
@@ -4,14 +4,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"runtime/debug"
+	"sync"
 	"time"
 
+	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// 预置的轮转周期，也可以直接给 Options.Rotation 赋值一个完整的 cron 表达式
+const (
+	Daily  = "@daily"
+	Hourly = "@hourly"
+)
+
 // A Level is a logging priority. Higher levels are more important.
 type Level int8
 
@@ -47,6 +57,44 @@ type Options struct {
 	MaxBackups int    // MaxBackups is the maximum number of old log files to retain
 	MaxAge     int    // MaxAge is the maximum number of days to retain old log files based on the timestamp encoded in their filename
 	Compress   bool
+
+	// LevelOutputs 按级别分流：每个级别可以单独指定一个带独立轮转策略的文件，
+	// 例如把 debug.log、info.log、warn.log、error.log、fatal.log 分开存放。
+	// 不影响上面 Filename 描述的默认文件输出，两者可以同时生效。
+	LevelOutputs map[Level]*FileOutput
+
+	// Rotation 按时间轮转 Filename，取值为 cron 表达式，也可以直接用 Daily/Hourly。
+	// 为空时不启用，沿用 MaxSize 触发轮转的方式。启用后 Filename 支持按
+	// time.Format 的参考时间写法嵌入时间，例如 "logs/app-2006-01-02.log"
+	Rotation string
+
+	// EncoderConfig 控制输出格式，为 nil 时使用默认的彩色 console 格式
+	EncoderConfig *EncoderConfig
+
+	// CrashLogFilename 不为空时，把 os.Stderr 重定向到这个文件，这样 Go
+	// runtime 自身的 panic / fatal error 输出（例如运行在 systemd 下看不到
+	// 标准错误的场景）也能落盘，而不是随进程退出一起丢失
+	CrashLogFilename string
+}
+
+// EncoderConfig 控制日志的编码格式，例如把 JSON 发给 ELK/Loki 的同时
+// 保留本地终端的彩色 console 输出
+type EncoderConfig struct {
+	Format            string // "json" 或 "console"，默认 "console"
+	EnableColor       bool   // console 格式下，级别是否带 ANSI 颜色
+	TimestampFormat   string // 时间格式，默认 ISO8601
+	DisableCaller     bool   // 不记录调用文件和行号
+	DisableStacktrace bool   // 设置了 EncoderConfig 时默认给 Error 及以上级别自动附加调用栈，置 true 关闭
+	LevelTruncation   bool   // 级别名截断为4个字符，例如 DEBUG -> DEBU
+}
+
+// FileOutput 描述单个日志文件的 lumberjack 轮转策略
+type FileOutput struct {
+	Filename   string
+	MaxSize    int // megabytes
+	MaxBackups int // MaxBackups is the maximum number of old log files to retain
+	MaxAge     int // MaxAge is the maximum number of days to retain old log files based on the timestamp encoded in their filename
+	Compress   bool
 }
 
 // 正在写入的日志信息
@@ -67,6 +115,10 @@ var (
 	fileWirtor *zapcore.WriteSyncer
 	// 写日志到标准输出
 	stdWirtor *zapcore.WriteSyncer
+	// 驱动 Rotation 按时间轮转文件的调度器，未启用 Rotation 时为 nil
+	rotationCron *cron.Cron
+	// 共享的日志级别，可以在进程运行期间通过 SetLevel/LevelHandler 动态调整
+	atomicLevel = zap.NewAtomicLevel()
 )
 
 func init() {
@@ -82,28 +134,230 @@ func init() {
 	})
 }
 
+// InitLogger 用 opt 初始化包级别的默认 logger。opt.Rotation 不是合法的 cron
+// 表达式时会 panic：InitLogger 没有 error 返回值，且这种失败意味着轮转配置
+// 从未生效，不应该让调用方在不知情的情况下继续跑，需要 error 返回值的场景
+// 请改用 New。
 func InitLogger(opt *Options, hooks ...func(LogInfo) error) {
-	var treeCore zapcore.Core
-	fileWirtor = getLogWriter(opt)
-	encoder := getEncoder()
+	if rotationCron != nil {
+		rotationCron.Stop()
+		rotationCron = nil
+	}
+
+	core, fw, sw, rc, err := buildCore(opt, atomicLevel)
+	if err != nil {
+		panic(err)
+	}
+	fileWirtor = fw
+	stdWirtor = sw
+	rotationCron = rc
+
+	if opt.CrashLogFilename != "" {
+		// 重定向失败不应该影响正常的日志功能，这里只尽力而为
+		_ = redirectStderr(opt.CrashLogFilename)
+	}
+
+	// AddCallerSkip，因为封装调用了zap的logger的方法，所以runtime.Caller层级必须修正，否则无法获取真实的日志调用位置
+	zl := zap.New(core, zap.AddCaller()).WithOptions(zapOptions(opt, hooks...)...)
+	logger = zl.Sugar()
+}
+
+// zapOptions 构建 InitLogger 和 New 共用的 zap.Option：统一挂载 hooks，
+// 并且只在调用方显式提供了 EncoderConfig、且没有设置 DisableStacktrace 时，
+// 才给 Error 及以上级别的日志自动附加调用栈（对应 Entry.Stack 和
+// EncoderConfig.StacktraceKey）。EncoderConfig 为 nil 的既有调用方保持
+// 原有行为不变，不会因为这个字段的引入而突然开始输出堆栈
+func zapOptions(opt *Options, hooks ...func(LogInfo) error) []zap.Option {
+	opts := []zap.Option{zap.AddCallerSkip(1), zap.Hooks(hooksHandler(hooks...)...)}
+	if opt.EncoderConfig != nil && !opt.EncoderConfig.DisableStacktrace {
+		opts = append(opts, zap.AddStacktrace(zapcore.ErrorLevel))
+	}
+	return opts
+}
+
+// buildCore 根据 opt 构建 InitLogger 和 New 共用的 zapcore.Core，以及这个
+// core 持有的文件 writer 和 Rotation 调度器。level 由调用方传入：InitLogger
+// 传入包级别共享的 atomicLevel，New 传入它自己独立的 AtomicLevel，这样
+// 通过 New 创建的 *Logger 调整级别时不会影响包级别的默认 logger，反之亦然。
+// opt.Rotation 不是合法的 cron 表达式时返回 error，调用方不应该当作轮转
+// 已经生效继续运行。
+func buildCore(opt *Options, level zap.AtomicLevel) (core zapcore.Core, fileWirtor, stdWirtor *zapcore.WriteSyncer, rotationCron *cron.Cron, err error) {
+	var cores []zapcore.Core
+
+	if opt.Rotation != "" && opt.Filename != "" {
+		rw := newRotatingWriter(opt)
+		var ws zapcore.WriteSyncer = rw
+		fileWirtor = &ws
+		rotationCron = cron.New()
+		if _, err = rotationCron.AddFunc(opt.Rotation, rw.rotate); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("logger: invalid Rotation %q: %w", opt.Rotation, err)
+		}
+		rotationCron.Start()
+	} else {
+		fileWirtor = getLogWriter(opt)
+	}
+	encoder := getEncoder(opt)
+	level.SetLevel(zapcore.Level(opt.Level))
 
 	if opt.NotStdout && opt.Filename != "" {
-		treeCore = zapcore.NewCore(encoder, *fileWirtor, zapcore.Level(opt.Level))
+		cores = append(cores, zapcore.NewCore(encoder, *fileWirtor, level))
 	} else if opt.Filename == "" && !opt.NotStdout {
 		sw := zapcore.AddSync(os.Stdout)
 		stdWirtor = &sw
-		treeCore = zapcore.NewCore(encoder, sw, zapcore.Level(opt.Level))
+		cores = append(cores, zapcore.NewCore(encoder, sw, level))
 	} else {
 		sw := zapcore.AddSync(os.Stdout)
 		stdWirtor = &sw
-		stdoutCore := zapcore.NewCore(encoder, sw, zapcore.Level(opt.Level))
-		fileCore := zapcore.NewCore(encoder, *fileWirtor, zapcore.Level(opt.Level))
-		treeCore = zapcore.NewTee(stdoutCore, fileCore)
+		cores = append(cores, zapcore.NewCore(encoder, sw, level))
+		cores = append(cores, zapcore.NewCore(encoder, *fileWirtor, level))
 	}
 
-	// AddCallerSkip，因为封装调用了zap的logger的方法，所以runtime.Caller层级必须修正，否则无法获取真实的日志调用位置
-	zl := zap.New(treeCore, zap.AddCaller()).WithOptions(zap.AddCallerSkip(1), zap.Hooks(hooksHandler(hooks...)...))
-	logger = zl.Sugar()
+	// 每个级别可以单独路由到自己的文件，各自按自己的轮转策略写入，
+	// 同时仍然受 level 控制，级别被调高后对应的文件也会停止写入
+	for lv, fo := range opt.LevelOutputs {
+		levelWirtor := getLevelWriter(fo)
+		matchLevel := zapcore.Level(lv)
+		enabler := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l == matchLevel && level.Enabled(l)
+		})
+		cores = append(cores, zapcore.NewCore(encoder, *levelWirtor, enabler))
+	}
+
+	return zapcore.NewTee(cores...), fileWirtor, stdWirtor, rotationCron, nil
+}
+
+// Field 是 zap.Field 的别名，调用方无需直接引入 zap 包即可构造结构化字段
+type Field = zap.Field
+
+// String 构造一个字符串字段
+func String(key, val string) Field {
+	return zap.String(key, val)
+}
+
+// Int 构造一个整型字段
+func Int(key string, val int) Field {
+	return zap.Int(key, val)
+}
+
+// Err 构造一个 error 字段，key 固定为 "error"
+func Err(err error) Field {
+	return zap.Error(err)
+}
+
+// Duration 构造一个 time.Duration 字段
+func Duration(key string, val time.Duration) Field {
+	return zap.Duration(key, val)
+}
+
+// Any 构造一个任意类型的字段，底层按反射选择合适的编码方式
+func Any(key string, val interface{}) Field {
+	return zap.Any(key, val)
+}
+
+// Logger 基于 zap.Field 的结构化日志接口。相比包级别的 sugared 函数，
+// 它不需要走 interface{} 反射，并且可以通过 With 携带 trace id、user id
+// 等请求级上下文，派生出子 Logger
+type Logger struct {
+	zl           *zap.Logger
+	rotationCron *cron.Cron
+	level        zap.AtomicLevel
+}
+
+// New 根据 opt 构建一个独立的 *Logger，和包级别的默认 logger 互不影响：它有
+// 自己的 AtomicLevel，调整这个 Logger（或者它派生出的子 Logger）的级别不会
+// 影响包级别的默认 logger，也不会影响其他 New() 出来的 *Logger
+func New(opt *Options, hooks ...func(LogInfo) error) (*Logger, error) {
+	level := zap.NewAtomicLevel()
+	core, _, _, rc, err := buildCore(opt, level)
+	if err != nil {
+		return nil, err
+	}
+	zl := zap.New(core, zap.AddCaller()).WithOptions(zapOptions(opt, hooks...)...)
+	return &Logger{zl: zl, rotationCron: rc, level: level}, nil
+}
+
+// With 返回一个带有附加字段的子 Logger，这些字段会自动带入后续的每一条日志
+func (l *Logger) With(fields ...Field) *Logger {
+	return &Logger{zl: l.zl.With(fields...), rotationCron: l.rotationCron, level: l.level}
+}
+
+// SetLevel 动态调整这个 Logger 的日志级别，只影响它自己和它派生出的子 Logger
+func (l *Logger) SetLevel(level Level) {
+	l.level.SetLevel(zapcore.Level(level))
+}
+
+// GetLevel 返回这个 Logger 当前生效的日志级别
+func (l *Logger) GetLevel() Level {
+	return Level(l.level.Level())
+}
+
+// Close 停止这个 Logger 自己的 Rotation 调度器，未启用 Rotation 时为空操作
+func (l *Logger) Close() {
+	if l.rotationCron != nil {
+		l.rotationCron.Stop()
+	}
+}
+
+// Debug logs a message at DebugLevel along with the given fields.
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.zl.Debug(msg, fields...)
+}
+
+// Info logs a message at InfoLevel along with the given fields.
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.zl.Info(msg, fields...)
+}
+
+// Warn logs a message at WarnLevel along with the given fields.
+func (l *Logger) Warn(msg string, fields ...Field) {
+	l.zl.Warn(msg, fields...)
+}
+
+// Error logs a message at ErrorLevel along with the given fields.
+func (l *Logger) Error(msg string, fields ...Field) {
+	l.zl.Error(msg, fields...)
+}
+
+// DPanic logs a message at DPanicLevel along with the given fields. In
+// development, the logger then panics. (See DPanicLevel for details.)
+func (l *Logger) DPanic(msg string, fields ...Field) {
+	l.zl.DPanic(msg, fields...)
+}
+
+// Panic logs a message at PanicLevel along with the given fields, then panics.
+func (l *Logger) Panic(msg string, fields ...Field) {
+	l.zl.Panic(msg, fields...)
+}
+
+// Fatal logs a message at FatalLevel along with the given fields, then calls os.Exit.
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.zl.Fatal(msg, fields...)
+}
+
+// Close 停止 Rotation 的调度器，未启用 Rotation 时为空操作。
+// 使用 Rotation 的调用方应当在进程退出前调用一次。
+func Close() {
+	if rotationCron != nil {
+		rotationCron.Stop()
+		rotationCron = nil
+	}
+}
+
+// SetLevel 动态调整日志级别，对已经通过 InitLogger 建立的所有 core 立即生效，无需重启进程
+func SetLevel(level Level) {
+	atomicLevel.SetLevel(zapcore.Level(level))
+}
+
+// GetLevel 返回当前生效的日志级别
+func GetLevel() Level {
+	return Level(atomicLevel.Level())
+}
+
+// LevelHandler 返回一个 http.Handler（GET 查看当前级别，PUT 传入 JSON 如
+// {"level":"info"} 动态修改），挂载到运维可访问的路由上即可在不重启进程的
+// 情况下调整日志级别
+func LevelHandler() http.Handler {
+	return atomicLevel
 }
 
 // 返回一个日志writer，可自定义处理
@@ -145,13 +399,53 @@ func GetDefault(filename string) *Options {
 	}
 }
 
-func getEncoder() zapcore.Encoder {
+func getEncoder(opt *Options) zapcore.Encoder {
+	ec := opt.EncoderConfig
+
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+
+	if ec == nil {
+		return zapcore.NewConsoleEncoder(encoderConfig)
+	}
+
+	if ec.TimestampFormat != "" {
+		layout := ec.TimestampFormat
+		encoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(t.Format(layout))
+		}
+	}
+	if ec.DisableCaller {
+		encoderConfig.CallerKey = zapcore.OmitKey
+	}
+	if ec.DisableStacktrace {
+		encoderConfig.StacktraceKey = zapcore.OmitKey
+	}
+	switch {
+	case ec.LevelTruncation:
+		encoderConfig.EncodeLevel = levelTruncatedEncoder
+	case ec.EnableColor && ec.Format != "json":
+		// ANSI 颜色码只对终端有意义，JSON 格式下会把颜色转义序列写进
+		// "level" 字段，破坏下游 ELK/Loki 的解析，所以 JSON 格式下忽略 EnableColor
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	if ec.Format == "json" {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
 	return zapcore.NewConsoleEncoder(encoderConfig)
 }
 
+// levelTruncatedEncoder 把级别名截断为4个字符，方便日志按列对齐
+func levelTruncatedEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	s := l.CapitalString()
+	if len(s) > 4 {
+		s = s[:4]
+	}
+	enc.AppendString(s)
+}
+
 func getLogWriter(opt *Options) *zapcore.WriteSyncer {
 	lumberJackLogger := &lumberjack.Logger{
 		Filename:   opt.Filename,
@@ -164,6 +458,59 @@ func getLogWriter(opt *Options) *zapcore.WriteSyncer {
 	return &ws
 }
 
+// rotatingWriter 包装 lumberjack.Logger，在 Rotation 调度器每次触发时
+// 重新计算 Filename（嵌入当前时间）并调用 Rotate，用互斥锁保证和
+// 正在进行的 Write 互斥。
+type rotatingWriter struct {
+	mu      sync.Mutex
+	ll      *lumberjack.Logger
+	pattern string
+}
+
+func newRotatingWriter(opt *Options) *rotatingWriter {
+	return &rotatingWriter{
+		pattern: opt.Filename,
+		ll: &lumberjack.Logger{
+			Filename:   time.Now().Format(opt.Filename),
+			MaxSize:    opt.MaxSize,
+			MaxBackups: opt.MaxBackups,
+			MaxAge:     opt.MaxAge,
+			Compress:   opt.Compress,
+		},
+	}
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ll.Write(p)
+}
+
+func (w *rotatingWriter) Sync() error {
+	return nil
+}
+
+// rotate 切到按当前时间命名的新文件，由 Rotation 的 cron 调度器定时调用
+func (w *rotatingWriter) rotate() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ll.Filename = time.Now().Format(w.pattern)
+	w.ll.Rotate()
+}
+
+// 获取单个级别专属文件的 writer
+func getLevelWriter(fo *FileOutput) *zapcore.WriteSyncer {
+	lumberJackLogger := &lumberjack.Logger{
+		Filename:   fo.Filename,
+		MaxSize:    fo.MaxSize,
+		MaxBackups: fo.MaxBackups,
+		MaxAge:     fo.MaxAge,
+		Compress:   fo.Compress,
+	}
+	ws := zapcore.AddSync(lumberJackLogger)
+	return &ws
+}
+
 // Debug uses fmt.Sprint to construct and log a message.
 func Debug(args ...interface{}) {
 	logger.Debug(args...)
@@ -305,3 +652,33 @@ func Puref(msg string, args ...interface{}) {
 		sw.Write([]byte(fmt.Sprintf(msg, args...) + "\n"))
 	}
 }
+
+// CaptureCrash 在 main 函数里 defer 调用，捕获 panic，把 PanicLevel 的堆栈
+// 信息通过正常的 core 写入（经过 EncoderConfig 编码、LevelOutputs 路由、
+// 注册的 hooks），再重新 panic，这样进程原本的崩溃行为（例如让 systemd
+// 感知到退出状态）不受影响。配合 CrashLogFilename 使用，Go runtime 自身的
+// 崩溃输出也不会再丢失。
+func CaptureCrash() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if logger != nil {
+		core := logger.Desugar().Core()
+		entry := zapcore.Entry{
+			Level:   zapcore.Level(PanicLevel),
+			Time:    time.Now(),
+			Message: fmt.Sprint(r),
+			Stack:   string(debug.Stack()),
+		}
+		// 必须走 Check/Write 协议：zap.Hooks() 包装出来的 core，Write 只会
+		// 触发注册的 hook，不会转发给被包装的 core，直接调用 Write 会跳过
+		// stdout/Filename/LevelOutputs 等真正的 sink
+		if ce := core.Check(entry, nil); ce != nil {
+			ce.Write()
+		}
+	}
+
+	panic(r)
+}
@@ -1,7 +1,15 @@
 package logger
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+
+	"go.uber.org/zap/zapcore"
 )
 
 func TestInitLogger(t *testing.T) {
@@ -35,3 +43,259 @@ func TestInitLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestLoggerWith(t *testing.T) {
+	l, err := New(&Options{
+		Level:    DebugLevel,
+		Filename: "",
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	child := l.With(String("module", "test"), Int("attempt", 1))
+	child.Info("hello", Err(nil))
+}
+
+func TestLevelOutputsRoutesPerLevel(t *testing.T) {
+	dir := t.TempDir()
+	errFile := filepath.Join(dir, "error.log")
+
+	l, err := New(&Options{
+		Level: DebugLevel,
+		LevelOutputs: map[Level]*FileOutput{
+			ErrorLevel: {Filename: errFile},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	l.Info("should not reach error.log")
+	l.Error("should reach error.log")
+
+	data, err := os.ReadFile(errFile)
+	if err != nil {
+		t.Fatalf("ReadFile(%q) error = %v", errFile, err)
+	}
+	if strings.Contains(string(data), "should not reach error.log") {
+		t.Fatalf("error.log contains an Info-level message: %q", data)
+	}
+	if !strings.Contains(string(data), "should reach error.log") {
+		t.Fatalf("error.log missing the Error-level message: %q", data)
+	}
+}
+
+func TestJSONEncoderIgnoresColor(t *testing.T) {
+	encoder := getEncoder(&Options{EncoderConfig: &EncoderConfig{Format: "json", EnableColor: true}})
+
+	buf, err := encoder.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: "hi"}, nil)
+	if err != nil {
+		t.Fatalf("EncodeEntry() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Fatalf("json encoder output contains ANSI color escapes: %q", buf.String())
+	}
+}
+
+func TestGetEncoderJSONFormat(t *testing.T) {
+	encoder := getEncoder(&Options{EncoderConfig: &EncoderConfig{Format: "json"}})
+
+	buf, err := encoder.EncodeEntry(zapcore.Entry{Level: zapcore.InfoLevel, Message: "hi"}, nil)
+	if err != nil {
+		t.Fatalf("EncodeEntry() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"level":"INFO"`) {
+		t.Fatalf("json encoder output missing capitalized level: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"msg":"hi"`) {
+		t.Fatalf("json encoder output missing message: %q", buf.String())
+	}
+}
+
+func TestDisableStacktrace(t *testing.T) {
+	var mu sync.Mutex
+	var got LogInfo
+
+	captureHook := func(info LogInfo) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = info
+		return nil
+	}
+
+	l, err := New(&Options{Level: DebugLevel}, captureHook)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	l.Error("boom")
+	mu.Lock()
+	if got.Stack != "" {
+		mu.Unlock()
+		t.Fatalf("Error() attached a stack with no EncoderConfig set, want unchanged pre-EncoderConfig behavior, got %q", got.Stack)
+	}
+	mu.Unlock()
+
+	l, err = New(&Options{Level: DebugLevel, EncoderConfig: &EncoderConfig{}}, captureHook)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	l.Error("boom")
+	mu.Lock()
+	if got.Stack == "" {
+		mu.Unlock()
+		t.Fatal("Error() did not attach a stack with EncoderConfig set, want a non-empty LogInfo.Stack")
+	}
+	mu.Unlock()
+
+	l, err = New(&Options{Level: DebugLevel, EncoderConfig: &EncoderConfig{DisableStacktrace: true}}, captureHook)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	l.Error("boom")
+	mu.Lock()
+	defer mu.Unlock()
+	if got.Stack != "" {
+		t.Fatalf("Error() attached a stack with DisableStacktrace set, got %q", got.Stack)
+	}
+}
+
+func TestRotationSchedulerStartsAndStops(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := New(&Options{
+		Level:    DebugLevel,
+		Filename: filepath.Join(dir, "app-20060102150405.log"),
+		Rotation: Daily,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	l.Info("hello")
+	l.Close() // Close 停止 cron 调度器；重复调用或调用后写日志都不应该 panic
+	l.Close()
+	l.Info("still works after Close")
+}
+
+func TestNewInvalidRotationReturnsError(t *testing.T) {
+	_, err := New(&Options{
+		Level:    DebugLevel,
+		Filename: "./log.log",
+		Rotation: "not-a-valid-cron-expr",
+	})
+	if err == nil {
+		t.Fatal("New() error = nil, want an error for an invalid Rotation cron expression")
+	}
+}
+
+func TestNewLoggerLevelIsIndependent(t *testing.T) {
+	InitLogger(&Options{Level: DebugLevel})
+	if got := GetLevel(); got != DebugLevel {
+		t.Fatalf("package level = %v, want DebugLevel", got)
+	}
+
+	l, err := New(&Options{Level: ErrorLevel})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if got := l.GetLevel(); got != ErrorLevel {
+		t.Fatalf("new logger level = %v, want ErrorLevel", got)
+	}
+	if got := GetLevel(); got != DebugLevel {
+		t.Fatalf("package level changed to %v after New(), want unchanged DebugLevel", got)
+	}
+}
+
+func TestLevelHandlerChangesLevel(t *testing.T) {
+	InitLogger(&Options{Level: InfoLevel})
+	if got := GetLevel(); got != InfoLevel {
+		t.Fatalf("package level = %v, want InfoLevel", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	LevelHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := GetLevel(); got != DebugLevel {
+		t.Fatalf("package level = %v after PUT, want DebugLevel", got)
+	}
+}
+
+func TestCaptureCrash(t *testing.T) {
+	InitLogger(&Options{Level: DebugLevel})
+
+	defer func() {
+		r := recover()
+		if r != "boom" {
+			t.Fatalf("recover() = %v, want %q", r, "boom")
+		}
+	}()
+	defer CaptureCrash()
+
+	panic("boom")
+}
+
+func TestCaptureCrashReachesHooks(t *testing.T) {
+	var mu sync.Mutex
+	var got LogInfo
+
+	InitLogger(&Options{Level: DebugLevel}, func(info LogInfo) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if info.Level == PanicLevel {
+			got = info
+		}
+		return nil
+	})
+
+	func() {
+		defer func() { recover() }()
+		defer CaptureCrash()
+		panic("boom")
+	}()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got.Level != PanicLevel {
+		t.Fatalf("hook never observed a PanicLevel LogInfo, got %+v", got)
+	}
+	if got.Message != "boom" {
+		t.Fatalf("hook LogInfo.Message = %q, want %q", got.Message, "boom")
+	}
+	if got.Stack == "" {
+		t.Fatalf("hook LogInfo.Stack is empty, want the captured panic stack")
+	}
+}
+
+func TestCaptureCrashReachesFileOutput(t *testing.T) {
+	dir := t.TempDir()
+	errFile := filepath.Join(dir, "error.log")
+
+	InitLogger(&Options{
+		Level: DebugLevel,
+		LevelOutputs: map[Level]*FileOutput{
+			PanicLevel: {Filename: errFile},
+		},
+	})
+
+	func() {
+		defer func() { recover() }()
+		defer CaptureCrash()
+		panic("boom")
+	}()
+
+	data, err := os.ReadFile(errFile)
+	if err != nil {
+		t.Fatalf("ReadFile(%q) error = %v", errFile, err)
+	}
+	if !strings.Contains(string(data), "boom") {
+		t.Fatalf("error.log missing the captured panic, got %q", data)
+	}
+}